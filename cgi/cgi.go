@@ -0,0 +1,163 @@
+// Package cgi implements a CGI/1.1 gateway (RFC 3875) so legacy scripts
+// can be mounted behind a shittp server without rewriting them in Go.
+package cgi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PrashantBtkl/shittp"
+)
+
+// Handler executes an external script per request and translates its
+// CGI/1.1 output into a response.
+type Handler struct {
+	// Path is the executable to run for every request.
+	Path string
+	// Root is the URL prefix Handler is mounted at; it's stripped from
+	// the request path to compute PATH_INFO.
+	Root string
+	// Dir is the working directory for the child process. Empty means
+	// the directory of Path.
+	Dir string
+	// Env holds extra environment variables passed to the child, on top
+	// of the standard CGI set.
+	Env []string
+	// Args holds extra arguments passed to the script.
+	Args []string
+}
+
+// ServeHTTP implements shittp.Handler.
+func (h *Handler) ServeHTTP(w shittp.ResponseWriter, r *shittp.HTTPRequest) {
+	path := r.URI
+	query := ""
+	if idx := strings.IndexByte(path, '?'); idx >= 0 {
+		query = path[idx+1:]
+		path = path[:idx]
+	}
+	pathInfo := strings.TrimPrefix(path, h.Root)
+
+	cmd := exec.Command(h.Path, h.Args...)
+	cmd.Dir = h.Dir
+	if cmd.Dir == "" {
+		cmd.Dir = filepath.Dir(h.Path)
+	}
+	cmd.Env = overrideEnv(h.cgiEnv(r, pathInfo, query), h.Env)
+
+	if r.Body == nil {
+		cmd.Stdin = strings.NewReader("")
+	} else {
+		cmd.Stdin = bytes.NewReader(r.Body)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		w.WriteHeader(502)
+		w.Write([]byte(fmt.Sprintf("CGI script %s failed: %v\n%s", h.Path, err, stderr.String())))
+		return
+	}
+
+	writeCGIOutput(w, &stdout)
+}
+
+// overrideEnv returns base with each entry in overrides applied on top:
+// an override replaces any existing base entry for the same key rather
+// than just being appended after it, so the result doesn't depend on
+// whatever duplicate-key behavior the eventual exec call happens to
+// have.
+func overrideEnv(base, overrides []string) []string {
+	result := make([]string, 0, len(base)+len(overrides))
+	for _, kv := range base {
+		key := kv
+		if idx := strings.IndexByte(kv, '='); idx >= 0 {
+			key = kv[:idx]
+		}
+		keep := true
+		for _, o := range overrides {
+			if idx := strings.IndexByte(o, '='); idx >= 0 && o[:idx] == key {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			result = append(result, kv)
+		}
+	}
+	return append(result, overrides...)
+}
+
+// cgiEnv builds the standard CGI/1.1 environment variables for r,
+// followed by the server process's own inherited environment.
+func (h *Handler) cgiEnv(r *shittp.HTTPRequest, pathInfo, query string) []string {
+	env := []string{
+		"GATEWAY_INTERFACE=CGI/1.1",
+		"REQUEST_METHOD=" + r.Method,
+		"SCRIPT_NAME=" + h.Root,
+		"PATH_INFO=" + pathInfo,
+		"QUERY_STRING=" + query,
+		"SERVER_PROTOCOL=" + r.Version,
+		"SERVER_SOFTWARE=shittp",
+	}
+
+	if v, ok := r.Headers["Content-Length"]; ok {
+		env = append(env, "CONTENT_LENGTH="+v)
+	}
+	if v, ok := r.Headers["Content-Type"]; ok {
+		env = append(env, "CONTENT_TYPE="+v)
+	}
+
+	for key, value := range r.Headers {
+		if key == "Content-Length" || key == "Content-Type" {
+			continue
+		}
+		name := "HTTP_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		env = append(env, name+"="+value)
+	}
+
+	return append(env, os.Environ()...)
+}
+
+// writeCGIOutput parses the CGI response produced by a script -- a
+// header block (Status:, Content-Type:, etc.) followed by a blank line
+// and the body -- and writes it through w.
+func writeCGIOutput(w shittp.ResponseWriter, out *bytes.Buffer) {
+	br := bufio.NewReader(out)
+	status := 200
+
+	for {
+		line, err := br.ReadString('\n')
+		trimmed := strings.TrimRight(line, "\r\n")
+		if trimmed == "" {
+			break
+		}
+		parts := strings.SplitN(trimmed, ":", 2)
+		if len(parts) == 2 {
+			key := strings.TrimSpace(parts[0])
+			value := strings.TrimSpace(parts[1])
+			if strings.EqualFold(key, "Status") {
+				if code, convErr := strconv.Atoi(strings.Fields(value)[0]); convErr == nil {
+					status = code
+				}
+			} else {
+				w.Header()[key] = value
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	w.WriteHeader(status)
+	body, _ := io.ReadAll(br)
+	w.Write(body)
+}
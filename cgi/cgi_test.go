@@ -0,0 +1,62 @@
+package cgi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/PrashantBtkl/shittp"
+)
+
+func TestHandlerServeHTTP(t *testing.T) {
+	h := &Handler{
+		Path: "/bin/sh",
+		Root: "/cgi-bin/",
+		Args: []string{"-c", `printf 'Content-Type: text/plain\r\n\r\nPATH_INFO=%s' "$PATH_INFO"`},
+	}
+
+	w := shittp.NewResponseRecorder()
+	r := &shittp.HTTPRequest{Method: "GET", URI: "/cgi-bin/greet/world", Headers: map[string]string{}}
+	h.ServeHTTP(w, r)
+
+	resp := w.Response()
+	if resp.Status != 200 {
+		t.Fatalf("status = %d, want 200", resp.Status)
+	}
+	if got, want := string(resp.Body), "PATH_INFO=greet/world"; got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
+
+func TestHandlerEnvOverridesInheritedVariable(t *testing.T) {
+	h := &Handler{
+		Path: "/bin/sh",
+		Root: "/",
+		Env:  []string{"SHITTP_TEST_VAR=overridden"},
+		Args: []string{"-c", `printf 'Content-Type: text/plain\r\n\r\n%s' "$SHITTP_TEST_VAR"`},
+	}
+
+	t.Setenv("SHITTP_TEST_VAR", "inherited")
+
+	w := shittp.NewResponseRecorder()
+	r := &shittp.HTTPRequest{Method: "GET", URI: "/", Headers: map[string]string{}}
+	h.ServeHTTP(w, r)
+
+	resp := w.Response()
+	if got, want := string(resp.Body), "overridden"; got != want {
+		t.Fatalf("body = %q, want %q (h.Env should win over the inherited environment)", got, want)
+	}
+}
+
+func TestWriteCGIOutputParsesStatusHeader(t *testing.T) {
+	out := bytes.NewBufferString("Status: 404 Not Found\r\nContent-Type: text/plain\r\n\r\nnope")
+	w := shittp.NewResponseRecorder()
+	writeCGIOutput(w, out)
+
+	resp := w.Response()
+	if resp.Status != 404 {
+		t.Fatalf("status = %d, want 404", resp.Status)
+	}
+	if string(resp.Body) != "nope" {
+		t.Fatalf("body = %q, want %q", resp.Body, "nope")
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/PrashantBtkl/shittp"
+)
+
+var log = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+func main() {
+	shittp.HandleFunc("GET", "/", func(w shittp.ResponseWriter, r *shittp.HTTPRequest) {
+		w.Header()["Content-Type"] = "text/plain"
+		w.Write([]byte("Hello, World!"))
+	})
+
+	srv := shittp.NewServer(":8080")
+	if err := srv.ListenAndServe(); err != nil {
+		log.Error("server exited", "error", err.Error())
+	}
+}
@@ -0,0 +1,396 @@
+package shittp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"runtime"
+	"syscall"
+)
+
+// connCtx is the per-connection state an event loop keeps across
+// read-ready callbacks: unlike a goroutine-per-conn worker, which can
+// just block on the next Read, a loop has to remember how much of the
+// next request it has already buffered between calls.
+type connCtx struct {
+	fd  int
+	buf []byte
+	// busy is true while a worker goroutine is running a handler for a
+	// request parsed off this connection's buffer. The owning loop
+	// goroutine is the only thing that reads or writes busy and buf
+	// while busy is false; while it's true, only the worker touches
+	// buf, so the two never race.
+	busy bool
+}
+
+// workResult reports the outcome of a worker's handleRequests run back
+// to the loop goroutine that owns the connection.
+type workResult struct {
+	fd    int
+	close bool
+}
+
+// workerPool runs handler dispatch on a fixed set of goroutines shared
+// by every event loop, so a slow handler blocks one worker instead of
+// the poller goroutine that every other connection on its loop depends
+// on.
+type workerPool struct {
+	jobs chan func()
+}
+
+func newWorkerPool(n int) *workerPool {
+	if n < 1 {
+		n = 1
+	}
+	p := &workerPool{jobs: make(chan func(), n)}
+	for i := 0; i < n; i++ {
+		go p.run()
+	}
+	return p
+}
+
+func (p *workerPool) run() {
+	for job := range p.jobs {
+		job()
+	}
+}
+
+func (p *workerPool) submit(job func()) {
+	p.jobs <- job
+}
+
+// eventLoop owns one poller and the connections assigned to it.
+type eventLoop struct {
+	server  *Server
+	poller  poller
+	conns   map[int]*connCtx
+	workers *workerPool
+	done    chan workResult
+}
+
+func newEventLoop(s *Server, workers *workerPool) (*eventLoop, error) {
+	p, err := newPoller()
+	if err != nil {
+		return nil, err
+	}
+	return &eventLoop{
+		server:  s,
+		poller:  p,
+		conns:   make(map[int]*connCtx),
+		workers: workers,
+		done:    make(chan workResult, 64),
+	}, nil
+}
+
+// register adds a freshly accepted, non-blocking fd to the loop.
+func (l *eventLoop) register(fd int) {
+	if err := l.poller.add(fd); err != nil {
+		log.Error("failed to register connection with poller", "error", err.Error())
+		syscall.Close(fd)
+		return
+	}
+	l.conns[fd] = &connCtx{fd: fd}
+}
+
+func (l *eventLoop) closeConn(fd int) {
+	l.poller.remove(fd)
+	delete(l.conns, fd)
+	syscall.Close(fd)
+}
+
+// run waits for read-ready fds and worker completions and handles each
+// until the poller returns an unrecoverable error. poller.wait blocks
+// on a syscall, so it runs on its own goroutine that feeds readyCh;
+// this goroutine is the sole owner of every connCtx in l.conns and
+// never blocks on handler execution, which workers run instead.
+func (l *eventLoop) run() {
+	readBuf := make([]byte, 64*1024)
+	readyCh := make(chan []int)
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			ready, err := l.poller.wait()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			readyCh <- ready
+		}
+	}()
+
+	for {
+		select {
+		case ready := <-readyCh:
+			for _, fd := range ready {
+				c, ok := l.conns[fd]
+				if !ok {
+					continue
+				}
+				l.handleReadable(c, readBuf)
+			}
+		case res := <-l.done:
+			c, ok := l.conns[res.fd]
+			if !ok {
+				continue
+			}
+			c.busy = false
+			if res.close {
+				l.closeConn(res.fd)
+				continue
+			}
+			// More data may have arrived on the socket while the
+			// worker was busy, and handleReadable ignored any
+			// readiness reported for it meanwhile; drain it now
+			// rather than waiting for the poller to report it again.
+			l.handleReadable(c, readBuf)
+		case err := <-errCh:
+			log.Error("poller wait failed", "error", err.Error())
+			return
+		}
+	}
+}
+
+// handleReadable drains whatever is available on c's fd into its
+// buffer, then hands off parsing and dispatch to a worker. If c is
+// already busy with an earlier worker run, it does nothing: the data
+// is still sitting in the socket's receive buffer and will be drained
+// once that worker reports back.
+func (l *eventLoop) handleReadable(c *connCtx, readBuf []byte) {
+	if c.busy {
+		return
+	}
+	for {
+		n, err := syscall.Read(c.fd, readBuf)
+		if n > 0 {
+			if !l.growBuffered(c, readBuf[:n]) {
+				return
+			}
+		}
+		if err != nil {
+			if err == syscall.EAGAIN {
+				break
+			}
+			l.closeConn(c.fd)
+			return
+		}
+		if n == 0 {
+			l.closeConn(c.fd)
+			return
+		}
+		if n < len(readBuf) {
+			break
+		}
+	}
+
+	if len(c.buf) == 0 {
+		return
+	}
+
+	c.busy = true
+	l.workers.submit(func() { l.handleRequests(c) })
+}
+
+// growBuffered appends b to c.buf, enforcing the same request-line,
+// header and body limits the blocking transport enforces, since
+// nothing else caps how much of an unterminated request this transport
+// buffers per connection. It reports false (having already responded
+// and closed the connection) once the buffered data exceeds whatever
+// limit framing has gotten to.
+func (l *eventLoop) growBuffered(c *connCtx, b []byte) bool {
+	c.buf = append(c.buf, b...)
+	if se := l.bufferedLimitError(c.buf); se != nil {
+		l.writeStatusErrorFd(c.fd, se)
+		l.closeConn(c.fd)
+		return false
+	}
+	return true
+}
+
+// bufferedLimitError reports the statusError buf, taken as a request
+// that hasn't finished framing yet, has already earned by exceeding
+// the server's configured size limits - or nil if it's still within
+// them.
+func (l *eventLoop) bufferedLimitError(buf []byte) *statusError {
+	lineEnd := bytes.IndexByte(buf, '\n')
+	if lineEnd < 0 {
+		if len(buf) > l.server.MaxRequestLineSize {
+			return &statusError{Status: 414, Reason: "URI Too Long"}
+		}
+		return nil
+	}
+	headerEnd := bytes.Index(buf, []byte("\r\n\r\n"))
+	if headerEnd < 0 {
+		if len(buf)-lineEnd-1 > l.server.MaxHeaderBytes {
+			return headerTooLarge(fmt.Errorf("header block exceeds %d bytes", l.server.MaxHeaderBytes))
+		}
+		return nil
+	}
+	bodySoFar := int64(len(buf) - headerEnd - 4)
+	if bodySoFar > l.server.MaxBodySize {
+		return bodyTooLarge(fmt.Errorf("body exceeds limit of %d bytes", l.server.MaxBodySize))
+	}
+	return nil
+}
+
+// handleRequests runs on a worker goroutine: it parses and dispatches
+// every complete request currently buffered on c, writing each
+// response in order, then reports back to c's loop whether the
+// connection should be closed.
+func (l *eventLoop) handleRequests(c *connCtx) {
+	closeAfter := false
+	for {
+		req, consumed, incomplete, err := l.server.tryParseBuffered(c.buf)
+		if incomplete {
+			break
+		}
+		if err != nil {
+			if se, ok := err.(*statusError); ok {
+				l.writeStatusErrorFd(c.fd, se)
+			}
+			closeAfter = true
+			break
+		}
+		c.buf = c.buf[consumed:]
+
+		handler := l.server.Handler
+		if handler == nil {
+			handler = DefaultServeMux
+		}
+		rw := NewResponseRecorder()
+		handler.ServeHTTP(rw, req)
+		resp := rw.Response()
+
+		keepAlive := shouldKeepAlive(req)
+		if !keepAlive {
+			resp.Headers["Connection"] = "close"
+		}
+		if err := l.writeResponseFd(c.fd, resp); err != nil {
+			closeAfter = true
+			break
+		}
+		if !keepAlive {
+			closeAfter = true
+			break
+		}
+	}
+	l.done <- workResult{fd: c.fd, close: closeAfter}
+}
+
+func (l *eventLoop) writeResponseFd(fd int, r *HTTPResponse) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "HTTP/%s %d %s\r\n", r.Version, r.Status, r.Reason)
+	for key, value := range r.Headers {
+		fmt.Fprintf(&b, "%s: %s\r\n", key, value)
+	}
+	b.WriteString("\r\n")
+	b.Write(r.Body)
+	return writeAllFd(fd, b.Bytes())
+}
+
+func (l *eventLoop) writeStatusErrorFd(fd int, se *statusError) {
+	body := []byte(se.Reason)
+	l.writeResponseFd(fd, &HTTPResponse{
+		Version: "1.1",
+		Status:  se.Status,
+		Reason:  se.Reason,
+		Headers: map[string]string{
+			"Content-Type":   "text/plain",
+			"Content-Length": fmt.Sprint(len(body)),
+		},
+		Body: body,
+	})
+}
+
+func writeAllFd(fd int, b []byte) error {
+	for len(b) > 0 {
+		n, err := syscall.Write(fd, b)
+		if err != nil {
+			if err == syscall.EAGAIN {
+				continue
+			}
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// tryParseBuffered attempts to parse a single request out of buf without
+// blocking. If buf doesn't yet hold a complete request it reports
+// incomplete=true so the caller can wait for more bytes to arrive rather
+// than treating a short read as malformed input.
+func (s *Server) tryParseBuffered(buf []byte) (req *HTTPRequest, consumed int, incomplete bool, err error) {
+	if len(buf) == 0 {
+		return nil, 0, true, nil
+	}
+	br := bufio.NewReaderSize(bytes.NewReader(buf), len(buf)+1)
+	req, err = s.parseIncomingRequest(br)
+	if err != nil {
+		if se, ok := err.(*statusError); ok && isIncompleteRead(se.Unwrap()) {
+			return nil, 0, true, nil
+		}
+		return nil, 0, false, err
+	}
+	return req, len(buf) - br.Buffered(), false, nil
+}
+
+func isIncompleteRead(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// serveEventLoop accepts connections with a single raw-syscall accept
+// loop and shards them across one or more event-loop goroutines, each
+// running its own poller.
+func (s *Server) serveEventLoop() error {
+	listenFd, err := listenTCP(s.Addr)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(listenFd)
+
+	n := 1
+	if s.Multicore {
+		n = runtime.NumCPU()
+	}
+	workers := s.Workers
+	if workers < 1 {
+		workers = defaultEventLoopWorkers
+	}
+	pool := newWorkerPool(workers)
+	loops := make([]*eventLoop, n)
+	for i := range loops {
+		l, err := newEventLoop(s, pool)
+		if err != nil {
+			return err
+		}
+		loops[i] = l
+		go l.run()
+	}
+
+	log.Info("Server listening (event-loop transport)", "addr", s.Addr, "loops", n)
+	for {
+		connFd, _, err := syscall.Accept(listenFd)
+		if err != nil {
+			if err == syscall.EAGAIN || err == syscall.EINTR {
+				continue
+			}
+			log.Error("accept failed", "error", err.Error())
+			continue
+		}
+		if err := syscall.SetNonblock(connFd, true); err != nil {
+			syscall.Close(connFd)
+			continue
+		}
+		loops[fdHash(connFd)%n].register(connFd)
+	}
+}
+
+// fdHash shards an accepted fd across event loops.
+func fdHash(fd int) int {
+	h := fnv.New32a()
+	fmt.Fprint(h, fd)
+	return int(h.Sum32())
+}
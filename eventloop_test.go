@@ -0,0 +1,85 @@
+package shittp
+
+import "testing"
+
+func TestTryParseBufferedIncompleteRequest(t *testing.T) {
+	s := NewServer(":0")
+	req, consumed, incomplete, err := s.tryParseBuffered([]byte("GET /x HTTP/1.1\r\nHost: "))
+	if err != nil {
+		t.Fatalf("tryParseBuffered: %v", err)
+	}
+	if !incomplete {
+		t.Fatal("incomplete = false, want true for a request with no terminating blank line")
+	}
+	if req != nil || consumed != 0 {
+		t.Fatalf("req = %v, consumed = %d, want nil, 0", req, consumed)
+	}
+}
+
+func TestTryParseBufferedCompleteRequest(t *testing.T) {
+	s := NewServer(":0")
+	raw := "GET /x HTTP/1.1\r\nHost: a\r\n\r\nGET /y HTTP/1.1\r\n\r\n"
+	req, consumed, incomplete, err := s.tryParseBuffered([]byte(raw))
+	if err != nil {
+		t.Fatalf("tryParseBuffered: %v", err)
+	}
+	if incomplete {
+		t.Fatal("incomplete = true, want false for a fully-framed request")
+	}
+	if req.URI != "/x" {
+		t.Fatalf("URI = %q, want %q", req.URI, "/x")
+	}
+	if consumed != len(raw)-len("GET /y HTTP/1.1\r\n\r\n") {
+		t.Fatalf("consumed = %d, want the byte length of the first request only", consumed)
+	}
+}
+
+func TestTryParseBufferedEmpty(t *testing.T) {
+	s := NewServer(":0")
+	_, _, incomplete, err := s.tryParseBuffered(nil)
+	if err != nil || !incomplete {
+		t.Fatalf("incomplete, err = %v, %v, want true, nil", incomplete, err)
+	}
+}
+
+func TestBufferedLimitErrorRequestLineTooLong(t *testing.T) {
+	s := NewServer(":0")
+	s.MaxRequestLineSize = 8
+	l := &eventLoop{server: s}
+
+	se := l.bufferedLimitError([]byte("GET /a-very-long-uri-with-no-newline-yet"))
+	if se == nil || se.Status != 414 {
+		t.Fatalf("bufferedLimitError = %v, want a 414 statusError", se)
+	}
+}
+
+func TestBufferedLimitErrorHeaderBlockTooLarge(t *testing.T) {
+	s := NewServer(":0")
+	s.MaxHeaderBytes = 8
+	l := &eventLoop{server: s}
+
+	se := l.bufferedLimitError([]byte("GET / HTTP/1.1\r\nX-One: 1\r\nX-Two: 2\r\n"))
+	if se == nil || se.Status != 431 {
+		t.Fatalf("bufferedLimitError = %v, want a 431 statusError", se)
+	}
+}
+
+func TestBufferedLimitErrorBodyTooLarge(t *testing.T) {
+	s := NewServer(":0")
+	s.MaxBodySize = 2
+	l := &eventLoop{server: s}
+
+	se := l.bufferedLimitError([]byte("GET / HTTP/1.1\r\n\r\nabc"))
+	if se == nil || se.Status != 413 {
+		t.Fatalf("bufferedLimitError = %v, want a 413 statusError", se)
+	}
+}
+
+func TestBufferedLimitErrorWithinLimits(t *testing.T) {
+	s := NewServer(":0")
+	l := &eventLoop{server: s}
+
+	if se := l.bufferedLimitError([]byte("GET / HTTP/1.1\r\nHost: a\r\n\r\n")); se != nil {
+		t.Fatalf("bufferedLimitError = %v, want nil", se)
+	}
+}
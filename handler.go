@@ -0,0 +1,132 @@
+package shittp
+
+import (
+	"fmt"
+)
+
+// Handler responds to an HTTP request.
+type Handler interface {
+	ServeHTTP(w ResponseWriter, r *HTTPRequest)
+}
+
+// HandlerFunc adapts an ordinary function to a Handler.
+type HandlerFunc func(w ResponseWriter, r *HTTPRequest)
+
+func (f HandlerFunc) ServeHTTP(w ResponseWriter, r *HTTPRequest) {
+	f(w, r)
+}
+
+// ResponseWriter lets a Handler build a response: set headers, pick a
+// status, then write the body. Headers are buffered and Content-Length
+// is computed automatically from the written body, so handlers never
+// have to track it themselves.
+type ResponseWriter interface {
+	Header() map[string]string
+	WriteHeader(status int)
+	Write(b []byte) (int, error)
+}
+
+// ResponseRecorder is the ResponseWriter implementation passed to
+// handlers. Its buffered state is turned into an HTTPResponse once the
+// handler returns.
+type ResponseRecorder struct {
+	header        map[string]string
+	status        int
+	body          []byte
+	headerWritten bool
+}
+
+// NewResponseRecorder returns a ResponseWriter that buffers the
+// handler's output in memory. Server uses one per request; callers that
+// need to drive a Handler without a live connection to write through
+// directly (such as the HTTP/2 adapter in tls.go) can use it too.
+func NewResponseRecorder() *ResponseRecorder {
+	return &ResponseRecorder{header: make(map[string]string)}
+}
+
+func (w *ResponseRecorder) Header() map[string]string {
+	return w.header
+}
+
+func (w *ResponseRecorder) WriteHeader(status int) {
+	if w.headerWritten {
+		return
+	}
+	w.status = status
+	w.headerWritten = true
+}
+
+func (w *ResponseRecorder) Write(b []byte) (int, error) {
+	if !w.headerWritten {
+		w.WriteHeader(200)
+	}
+	w.body = append(w.body, b...)
+	return len(b), nil
+}
+
+// Response assembles the buffered handler output into an HTTPResponse,
+// filling in Content-Length and a default status/reason if the handler
+// never called WriteHeader.
+func (w *ResponseRecorder) Response() *HTTPResponse {
+	status := w.status
+	if !w.headerWritten {
+		status = 200
+	}
+	w.header["Content-Length"] = fmt.Sprint(len(w.body))
+	return &HTTPResponse{
+		Version: "1.1",
+		Status:  status,
+		Reason:  statusReason(status),
+		Headers: w.header,
+		Body:    w.body,
+	}
+}
+
+// statusReason returns the standard reason phrase for the handful of
+// statuses this server generates itself.
+func statusReason(status int) string {
+	switch status {
+	case 200:
+		return "OK"
+	case 201:
+		return "Created"
+	case 204:
+		return "No Content"
+	case 301:
+		return "Moved Permanently"
+	case 302:
+		return "Found"
+	case 400:
+		return "Bad Request"
+	case 404:
+		return "Not Found"
+	case 405:
+		return "Method Not Allowed"
+	case 413:
+		return "Payload Too Large"
+	case 414:
+		return "URI Too Long"
+	case 431:
+		return "Request Header Fields Too Large"
+	case 500:
+		return "Internal Server Error"
+	case 502:
+		return "Bad Gateway"
+	default:
+		return "Unknown"
+	}
+}
+
+// DefaultServeMux is the mux used by Handle, HandleFunc, and any Server
+// whose Handler field is left nil, mirroring net/http.
+var DefaultServeMux = NewServeMux()
+
+// Handle registers handler for method and pattern on DefaultServeMux.
+func Handle(method, pattern string, handler Handler) {
+	DefaultServeMux.Handle(method, pattern, handler)
+}
+
+// HandleFunc registers handler for method and pattern on DefaultServeMux.
+func HandleFunc(method, pattern string, handler func(ResponseWriter, *HTTPRequest)) {
+	DefaultServeMux.HandleFunc(method, pattern, handler)
+}
@@ -0,0 +1,258 @@
+package http2
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Request is a request decoded off an HTTP/2 stream.
+type Request struct {
+	Method  string
+	Path    string
+	Headers map[string]string
+	Body    []byte
+}
+
+// ResponseWriter lets a Handler build the response for a stream.
+// Headers are buffered and Content-Length is computed automatically, so
+// handlers never have to track it themselves.
+type ResponseWriter interface {
+	Header() map[string]string
+	WriteHeader(status int)
+	Write(b []byte) (int, error)
+}
+
+// Handler responds to a request decoded off an HTTP/2 stream. shittp
+// adapts its own Handler type to this interface in tls.go, rather than
+// http2 depending on shittp's types directly, to avoid an import cycle
+// between the two packages.
+type Handler interface {
+	ServeHTTP(w ResponseWriter, r *Request)
+}
+
+// responseRecorder buffers a stream's response until the handler
+// returns, so Content-Length can be computed before anything is
+// written.
+type responseRecorder struct {
+	header        map[string]string
+	status        int
+	body          []byte
+	headerWritten bool
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(map[string]string)}
+}
+
+func (w *responseRecorder) Header() map[string]string { return w.header }
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if w.headerWritten {
+		return
+	}
+	w.status = status
+	w.headerWritten = true
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if !w.headerWritten {
+		w.WriteHeader(200)
+	}
+	w.body = append(w.body, b...)
+	return len(b), nil
+}
+
+// stream accumulates one HTTP/2 stream's request until END_STREAM
+// arrives, then it's dispatched through the connection's Handler.
+type stream struct {
+	headers []HeaderField
+	body    []byte
+
+	// headerBlock buffers the raw HPACK bytes of a HEADERS frame and any
+	// CONTINUATION frames that follow it; a client is free to split a
+	// single header block across frames (RFC 7540 section 4.3), and the
+	// block can only be decoded once FlagEndHeaders marks it complete.
+	headerBlock      []byte
+	headersPending   bool
+	endStreamPending bool
+}
+
+// Serve drives a single HTTP/2 connection to completion: it checks the
+// connection preface, exchanges an initial SETTINGS frame, then
+// multiplexes HEADERS/DATA/WINDOW_UPDATE/PING frames across streams,
+// calling handler once each stream's request is fully received.
+func Serve(conn net.Conn, handler Handler) error {
+	var preface [len(Preface)]byte
+	if _, err := io.ReadFull(conn, preface[:]); err != nil {
+		return fmt.Errorf("http2: reading preface: %w", err)
+	}
+	if string(preface[:]) != Preface {
+		return fmt.Errorf("http2: bad connection preface")
+	}
+
+	framer := NewFramer(conn, conn)
+
+	// An empty SETTINGS frame accepts the client's defaults. A server
+	// with its own limits to advertise (MAX_CONCURRENT_STREAMS, initial
+	// window size, ...) would list them as (identifier, value) pairs
+	// here instead.
+	if err := framer.WriteFrame(FrameSettings, 0, 0, nil); err != nil {
+		return err
+	}
+
+	dyn := &dynamicTable{maxSize: 4096}
+	streams := make(map[uint32]*stream)
+
+	for {
+		fh, payload, err := framer.ReadFrame()
+		if err != nil {
+			return err
+		}
+
+		switch fh.Type {
+		case FrameSettings:
+			if fh.Flags&FlagAck == 0 {
+				if err := framer.WriteFrame(FrameSettings, FlagAck, 0, nil); err != nil {
+					return err
+				}
+			}
+
+		case FramePing:
+			if fh.Flags&FlagAck == 0 {
+				if err := framer.WriteFrame(FramePing, FlagAck, 0, payload); err != nil {
+					return err
+				}
+			}
+
+		case FrameWindowUpdate:
+			// Flow control isn't enforced yet, so updates are a no-op.
+
+		case FrameHeaders:
+			st := streams[fh.StreamID]
+			if st == nil {
+				st = &stream{}
+				streams[fh.StreamID] = st
+			}
+			block := stripPadding(payload, fh.Flags)
+			if fh.Flags&FlagPriority != 0 && len(block) >= 5 {
+				block = block[5:] // stream dependency + weight, unused here
+			}
+			st.headerBlock = append(st.headerBlock, block...)
+			st.headersPending = true
+			st.endStreamPending = fh.Flags&FlagEndStream != 0
+			if fh.Flags&FlagEndHeaders != 0 {
+				if err := finishHeaders(framer, fh.StreamID, st, dyn, handler, streams); err != nil {
+					return err
+				}
+			}
+
+		case FrameContinuation:
+			st := streams[fh.StreamID]
+			if st == nil || !st.headersPending {
+				return fmt.Errorf("http2: CONTINUATION frame for stream %d with no pending header block", fh.StreamID)
+			}
+			st.headerBlock = append(st.headerBlock, payload...)
+			if fh.Flags&FlagEndHeaders != 0 {
+				if err := finishHeaders(framer, fh.StreamID, st, dyn, handler, streams); err != nil {
+					return err
+				}
+			}
+
+		case FrameData:
+			st := streams[fh.StreamID]
+			if st == nil {
+				continue
+			}
+			st.body = append(st.body, stripPadding(payload, fh.Flags)...)
+			if fh.Flags&FlagEndStream != 0 {
+				if err := dispatchStream(framer, fh.StreamID, st, handler); err != nil {
+					return err
+				}
+				delete(streams, fh.StreamID)
+			}
+
+		case FrameGoAway:
+			return nil
+
+		default:
+			// PRIORITY, RST_STREAM, PUSH_PROMISE: not handled in this
+			// first pass.
+		}
+	}
+}
+
+// finishHeaders decodes a stream's accumulated header block once
+// FlagEndHeaders marks it complete, then dispatches the stream if
+// FlagEndStream arrived along with the headers.
+func finishHeaders(framer *Framer, streamID uint32, st *stream, dyn *dynamicTable, handler Handler, streams map[uint32]*stream) error {
+	fields, err := DecodeHeaders(st.headerBlock, dyn)
+	if err != nil {
+		return fmt.Errorf("http2: decoding headers: %w", err)
+	}
+	st.headers = append(st.headers, fields...)
+	st.headerBlock = nil
+	st.headersPending = false
+
+	if st.endStreamPending {
+		if err := dispatchStream(framer, streamID, st, handler); err != nil {
+			return err
+		}
+		delete(streams, streamID)
+	}
+	return nil
+}
+
+// dispatchStream turns a stream's accumulated headers and body into a
+// Request, runs it through handler, and writes the result back as a
+// HEADERS frame followed by a DATA frame.
+func dispatchStream(framer *Framer, streamID uint32, st *stream, handler Handler) error {
+	req := &Request{Headers: make(map[string]string), Body: st.body}
+	for _, f := range st.headers {
+		switch f.Name {
+		case ":method":
+			req.Method = f.Value
+		case ":path":
+			req.Path = f.Value
+		case ":authority":
+			req.Headers["Host"] = f.Value
+		case ":scheme":
+			// Request has nowhere to put this yet; dropped.
+		default:
+			req.Headers[canonicalHeaderName(f.Name)] = f.Value
+		}
+	}
+
+	rw := newResponseRecorder()
+	handler.ServeHTTP(rw, req)
+	status := rw.status
+	if !rw.headerWritten {
+		status = 200
+	}
+	rw.header["Content-Length"] = strconv.Itoa(len(rw.body))
+
+	respFields := []HeaderField{{Name: ":status", Value: strconv.Itoa(status)}}
+	for k, v := range rw.header {
+		respFields = append(respFields, HeaderField{Name: strings.ToLower(k), Value: v})
+	}
+	if err := framer.WriteFrame(FrameHeaders, FlagEndHeaders, streamID, EncodeHeaders(respFields)); err != nil {
+		return err
+	}
+	return framer.WriteFrame(FrameData, FlagEndStream, streamID, rw.body)
+}
+
+// canonicalHeaderName converts an HTTP/2 lowercase header name (e.g.
+// "content-type") to the Title-Case form shittp stores HTTP/1.1 headers
+// under (e.g. "Content-Type"), so handlers can read a request's headers
+// the same way regardless of which protocol version it arrived on.
+func canonicalHeaderName(name string) string {
+	parts := strings.Split(name, "-")
+	for i, p := range parts {
+		if len(p) > 0 {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "-")
+}
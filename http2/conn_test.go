@@ -0,0 +1,95 @@
+package http2
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+type testHandler struct {
+	gotMethod string
+	gotPath   string
+}
+
+func (h *testHandler) ServeHTTP(w ResponseWriter, r *Request) {
+	h.gotMethod = r.Method
+	h.gotPath = r.Path
+	w.Write([]byte("ok"))
+}
+
+// TestServeHandlesContinuationFrame splits a single HEADERS block across
+// a HEADERS frame (without FlagEndHeaders) and a CONTINUATION frame
+// (with FlagEndHeaders), the way a client is allowed to per RFC 7540
+// section 4.3, and checks the request still gets dispatched once the
+// block completes.
+func TestServeHandlesContinuationFrame(t *testing.T) {
+	client, serverConn := net.Pipe()
+	handler := &testHandler{}
+	done := make(chan error, 1)
+	go func() { done <- Serve(serverConn, handler) }()
+
+	clientFramer := NewFramer(client, client)
+
+	go client.Write([]byte(Preface))
+
+	// The server always sends an initial SETTINGS frame.
+	if fh, _, err := clientFramer.ReadFrame(); err != nil || fh.Type != FrameSettings {
+		t.Fatalf("initial frame = %+v, err = %v, want a SETTINGS frame", fh, err)
+	}
+
+	block := EncodeHeaders([]HeaderField{
+		{Name: ":method", Value: "GET"},
+		{Name: ":path", Value: "/greet"},
+	})
+	if len(block) < 2 {
+		t.Fatalf("encoded header block too short to split: %d bytes", len(block))
+	}
+	split := len(block) / 2
+
+	go func() {
+		clientFramer.WriteFrame(FrameHeaders, FlagEndStream, 1, block[:split])
+		clientFramer.WriteFrame(FrameContinuation, FlagEndHeaders, 1, block[split:])
+	}()
+
+	fh, respHeaderBlock, err := clientFramer.ReadFrame()
+	if err != nil {
+		t.Fatalf("reading response HEADERS: %v", err)
+	}
+	if fh.Type != FrameHeaders || fh.Flags&FlagEndHeaders == 0 {
+		t.Fatalf("response frame = %+v, want a HEADERS frame with FlagEndHeaders", fh)
+	}
+
+	dyn := &dynamicTable{maxSize: 4096}
+	respFields, err := DecodeHeaders(respHeaderBlock, dyn)
+	if err != nil {
+		t.Fatalf("decoding response headers: %v", err)
+	}
+	var status string
+	for _, f := range respFields {
+		if f.Name == ":status" {
+			status = f.Value
+		}
+	}
+	if status != "200" {
+		t.Fatalf("status = %q, want %q", status, "200")
+	}
+
+	dataFh, body, err := clientFramer.ReadFrame()
+	if err != nil {
+		t.Fatalf("reading response DATA: %v", err)
+	}
+	if dataFh.Type != FrameData || string(body) != "ok" {
+		t.Fatalf("response body = %q, frame = %+v", body, dataFh)
+	}
+
+	if handler.gotMethod != "GET" || handler.gotPath != "/greet" {
+		t.Fatalf("handler saw method=%q path=%q, want GET /greet", handler.gotMethod, handler.gotPath)
+	}
+
+	client.Close()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return after the client closed the connection")
+	}
+}
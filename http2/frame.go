@@ -0,0 +1,127 @@
+// Package http2 implements enough of RFC 7540 (HTTP/2) to serve
+// requests negotiated via TLS ALPN: the connection preface, frame
+// framing, a minimal HPACK codec, and single-connection stream
+// multiplexing over the HEADERS/DATA/SETTINGS/WINDOW_UPDATE frames.
+package http2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Preface is the HTTP/2 connection preface every client sends before
+// any frames (RFC 7540 section 3.5).
+const Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// maxFrameSize is the largest frame payload ReadFrame will allocate for,
+// matching the SETTINGS_MAX_FRAME_SIZE default from RFC 7540 section
+// 6.5.2. The wire length field is 24 bits wide (up to ~16MB), so without
+// this a client can force repeated 16MB allocations; the server never
+// advertises a larger SETTINGS_MAX_FRAME_SIZE, so a well-behaved client
+// never needs more than this.
+const maxFrameSize = 1 << 14
+
+// Frame types (RFC 7540 section 11.2).
+const (
+	FrameData         = 0x0
+	FrameHeaders      = 0x1
+	FramePriority     = 0x2
+	FrameRSTStream    = 0x3
+	FrameSettings     = 0x4
+	FramePushPromise  = 0x5
+	FramePing         = 0x6
+	FrameGoAway       = 0x7
+	FrameWindowUpdate = 0x8
+	FrameContinuation = 0x9
+)
+
+// Frame flags used by the frame types above.
+const (
+	FlagAck        = 0x1
+	FlagEndStream  = 0x1
+	FlagEndHeaders = 0x4
+	FlagPadded     = 0x8
+	FlagPriority   = 0x20
+)
+
+// FrameHeader is the 9-byte header that precedes every frame's payload.
+type FrameHeader struct {
+	Length   uint32 // 24 bits on the wire
+	Type     uint8
+	Flags    uint8
+	StreamID uint32 // 31 bits on the wire
+}
+
+// Framer reads and writes HTTP/2 frames on a single connection.
+type Framer struct {
+	r io.Reader
+	w io.Writer
+}
+
+// NewFramer returns a Framer reading from r and writing to w.
+func NewFramer(r io.Reader, w io.Writer) *Framer {
+	return &Framer{r: r, w: w}
+}
+
+// ReadFrame reads the next frame's header and payload.
+func (f *Framer) ReadFrame() (FrameHeader, []byte, error) {
+	var hdr [9]byte
+	if _, err := io.ReadFull(f.r, hdr[:]); err != nil {
+		return FrameHeader{}, nil, err
+	}
+	length := uint32(hdr[0])<<16 | uint32(hdr[1])<<8 | uint32(hdr[2])
+	fh := FrameHeader{
+		Length:   length,
+		Type:     hdr[3],
+		Flags:    hdr[4],
+		StreamID: binary.BigEndian.Uint32(hdr[5:9]) & 0x7fffffff,
+	}
+	if length > maxFrameSize {
+		return FrameHeader{}, nil, fmt.Errorf("http2: frame payload of %d bytes exceeds max frame size of %d", length, maxFrameSize)
+	}
+	payload := make([]byte, length)
+	if length > 0 {
+		if _, err := io.ReadFull(f.r, payload); err != nil {
+			return FrameHeader{}, nil, err
+		}
+	}
+	return fh, payload, nil
+}
+
+// WriteFrame writes a single frame.
+func (f *Framer) WriteFrame(typ, flags uint8, streamID uint32, payload []byte) error {
+	if len(payload) > 0xffffff {
+		return fmt.Errorf("http2: frame payload too large: %d bytes", len(payload))
+	}
+	var hdr [9]byte
+	hdr[0] = byte(len(payload) >> 16)
+	hdr[1] = byte(len(payload) >> 8)
+	hdr[2] = byte(len(payload))
+	hdr[3] = typ
+	hdr[4] = flags
+	binary.BigEndian.PutUint32(hdr[5:9], streamID&0x7fffffff)
+	if _, err := f.w.Write(hdr[:]); err != nil {
+		return err
+	}
+	if len(payload) > 0 {
+		if _, err := f.w.Write(payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stripPadding removes a PADDED frame's length-prefixed padding,
+// returning just the frame's actual content.
+func stripPadding(payload []byte, flags uint8) []byte {
+	if flags&FlagPadded == 0 || len(payload) == 0 {
+		return payload
+	}
+	padLen := int(payload[0])
+	payload = payload[1:]
+	if padLen > len(payload) {
+		return nil
+	}
+	return payload[:len(payload)-padLen]
+}
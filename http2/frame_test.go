@@ -0,0 +1,69 @@
+package http2
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFramerWriteReadRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewFramer(&buf, &buf)
+
+	payload := []byte("hello")
+	if err := f.WriteFrame(FrameData, FlagEndStream, 3, payload); err != nil {
+		t.Fatalf("WriteFrame: %v", err)
+	}
+
+	fh, got, err := f.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame: %v", err)
+	}
+	if fh.Type != FrameData || fh.Flags != FlagEndStream || fh.StreamID != 3 {
+		t.Fatalf("frame header = %+v", fh)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("payload = %q, want %q", got, "hello")
+	}
+}
+
+func TestWriteFrameRejectsOversizedPayload(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewFramer(&buf, &buf)
+	if err := f.WriteFrame(FrameData, 0, 1, make([]byte, 0x1000000)); err == nil {
+		t.Fatal("expected an error for a payload over the 24-bit length field")
+	}
+}
+
+func TestReadFrameRejectsOversizedLength(t *testing.T) {
+	// A frame header claiming a length bigger than maxFrameSize, with no
+	// payload actually behind it. ReadFrame must reject it before
+	// allocating, rather than trying to read maxFrameSize+1 bytes.
+	var hdr [9]byte
+	length := maxFrameSize + 1
+	hdr[0] = byte(length >> 16)
+	hdr[1] = byte(length >> 8)
+	hdr[2] = byte(length)
+	hdr[3] = FrameData
+
+	f := NewFramer(bytes.NewReader(hdr[:]), nil)
+	if _, _, err := f.ReadFrame(); err == nil {
+		t.Fatal("expected an error for a frame length over maxFrameSize")
+	}
+}
+
+func TestStripPadding(t *testing.T) {
+	// padLen=2, 3 bytes of content, then 2 bytes of padding.
+	payload := []byte{2, 'a', 'b', 'c', 0, 0}
+	got := stripPadding(payload, FlagPadded)
+	if string(got) != "abc" {
+		t.Fatalf("stripPadding = %q, want %q", got, "abc")
+	}
+}
+
+func TestStripPaddingNoFlag(t *testing.T) {
+	payload := []byte("abc")
+	got := stripPadding(payload, 0)
+	if string(got) != "abc" {
+		t.Fatalf("stripPadding = %q, want %q", got, "abc")
+	}
+}
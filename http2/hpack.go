@@ -0,0 +1,274 @@
+package http2
+
+import "fmt"
+
+// HeaderField is a decoded (name, value) pair.
+type HeaderField struct {
+	Name  string
+	Value string
+}
+
+// staticTable is the fixed HPACK static table (RFC 7541 Appendix A).
+var staticTable = [...]HeaderField{
+	{":authority", ""},
+	{":method", "GET"},
+	{":method", "POST"},
+	{":path", "/"},
+	{":path", "/index.html"},
+	{":scheme", "http"},
+	{":scheme", "https"},
+	{":status", "200"},
+	{":status", "204"},
+	{":status", "206"},
+	{":status", "304"},
+	{":status", "400"},
+	{":status", "404"},
+	{":status", "500"},
+	{"accept-charset", ""},
+	{"accept-encoding", "gzip, deflate"},
+	{"accept-language", ""},
+	{"accept-ranges", ""},
+	{"accept", ""},
+	{"access-control-allow-origin", ""},
+	{"age", ""},
+	{"allow", ""},
+	{"authorization", ""},
+	{"cache-control", ""},
+	{"content-disposition", ""},
+	{"content-encoding", ""},
+	{"content-language", ""},
+	{"content-length", ""},
+	{"content-location", ""},
+	{"content-range", ""},
+	{"content-type", ""},
+	{"cookie", ""},
+	{"date", ""},
+	{"etag", ""},
+	{"expect", ""},
+	{"expires", ""},
+	{"from", ""},
+	{"host", ""},
+	{"if-match", ""},
+	{"if-modified-since", ""},
+	{"if-none-match", ""},
+	{"if-range", ""},
+	{"if-unmodified-since", ""},
+	{"last-modified", ""},
+	{"link", ""},
+	{"location", ""},
+	{"max-forwards", ""},
+	{"proxy-authenticate", ""},
+	{"proxy-authorization", ""},
+	{"range", ""},
+	{"referer", ""},
+	{"refresh", ""},
+	{"retry-after", ""},
+	{"server", ""},
+	{"set-cookie", ""},
+	{"strict-transport-security", ""},
+	{"transfer-encoding", ""},
+	{"user-agent", ""},
+	{"vary", ""},
+	{"via", ""},
+	{"www-authenticate", ""},
+}
+
+// dynamicTable is a minimal HPACK dynamic table: entries are added by
+// incremental indexing and evicted oldest-first once maxSize is
+// exceeded, per RFC 7541 section 4.1.
+type dynamicTable struct {
+	entries []HeaderField
+	size    int
+	maxSize int
+}
+
+func (t *dynamicTable) add(f HeaderField) {
+	entrySize := len(f.Name) + len(f.Value) + 32
+	t.entries = append([]HeaderField{f}, t.entries...)
+	t.size += entrySize
+	for t.size > t.maxSize && len(t.entries) > 0 {
+		last := t.entries[len(t.entries)-1]
+		t.entries = t.entries[:len(t.entries)-1]
+		t.size -= len(last.Name) + len(last.Value) + 32
+	}
+}
+
+func (t *dynamicTable) get(index int) (HeaderField, bool) {
+	if index < 0 || index >= len(t.entries) {
+		return HeaderField{}, false
+	}
+	return t.entries[index], true
+}
+
+// lookup resolves a 1-based HPACK index against the static table
+// followed by the dynamic table.
+func lookup(dyn *dynamicTable, index int) (HeaderField, bool) {
+	if index >= 1 && index <= len(staticTable) {
+		return staticTable[index-1], true
+	}
+	return dyn.get(index - len(staticTable) - 1)
+}
+
+// decodeInt decodes an HPACK variable-length integer with the given
+// prefix length in bits (RFC 7541 section 5.1), returning the value and
+// the number of bytes consumed.
+func decodeInt(buf []byte, prefixBits int) (value, consumed int, err error) {
+	if len(buf) == 0 {
+		return 0, 0, fmt.Errorf("hpack: empty integer")
+	}
+	mask := byte(1<<prefixBits) - 1
+	value = int(buf[0] & mask)
+	if value < int(mask) {
+		return value, 1, nil
+	}
+	shift := 0
+	for i := 1; ; i++ {
+		if i >= len(buf) {
+			return 0, 0, fmt.Errorf("hpack: truncated integer")
+		}
+		b := buf[i]
+		value += int(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return value, i + 1, nil
+		}
+	}
+}
+
+// decodeString decodes an HPACK string literal (RFC 7541 section 5.2),
+// including Huffman-coded literals (the length byte's high bit).
+func decodeString(buf []byte) (s string, consumed int, err error) {
+	if len(buf) == 0 {
+		return "", 0, fmt.Errorf("hpack: empty string literal")
+	}
+	huffman := buf[0]&0x80 != 0
+	length, n, err := decodeInt(buf, 7)
+	if err != nil {
+		return "", 0, err
+	}
+	if n+length > len(buf) {
+		return "", 0, fmt.Errorf("hpack: truncated string literal")
+	}
+	raw := buf[n : n+length]
+	if huffman {
+		s, err = huffmanDecode(raw)
+		if err != nil {
+			return "", 0, err
+		}
+		return s, n + length, nil
+	}
+	return string(raw), n + length, nil
+}
+
+// DecodeHeaders decodes an HPACK header block into an ordered list of
+// header fields, using dyn as the connection's dynamic table.
+func DecodeHeaders(buf []byte, dyn *dynamicTable) ([]HeaderField, error) {
+	var fields []HeaderField
+	for len(buf) > 0 {
+		b := buf[0]
+		switch {
+		case b&0x80 != 0: // indexed header field
+			index, n, err := decodeInt(buf, 7)
+			if err != nil {
+				return nil, err
+			}
+			f, ok := lookup(dyn, index)
+			if !ok {
+				return nil, fmt.Errorf("hpack: invalid index %d", index)
+			}
+			fields = append(fields, f)
+			buf = buf[n:]
+
+		case b&0x40 != 0: // literal header field with incremental indexing
+			index, n, err := decodeInt(buf, 6)
+			if err != nil {
+				return nil, err
+			}
+			f, consumed, err := decodeLiteralField(buf[n:], dyn, index)
+			if err != nil {
+				return nil, err
+			}
+			buf = buf[n+consumed:]
+			fields = append(fields, f)
+			dyn.add(f)
+
+		case b&0x20 != 0: // dynamic table size update
+			size, n, err := decodeInt(buf, 5)
+			if err != nil {
+				return nil, err
+			}
+			dyn.maxSize = size
+			buf = buf[n:]
+
+		default: // literal without indexing / never indexed
+			index, n, err := decodeInt(buf, 4)
+			if err != nil {
+				return nil, err
+			}
+			f, consumed, err := decodeLiteralField(buf[n:], dyn, index)
+			if err != nil {
+				return nil, err
+			}
+			buf = buf[n+consumed:]
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// decodeLiteralField decodes the value (and, if nameIndex == 0, the
+// name) of a literal header field, starting right after the field's
+// prefix byte(s).
+func decodeLiteralField(buf []byte, dyn *dynamicTable, nameIndex int) (HeaderField, int, error) {
+	var name string
+	consumed := 0
+	if nameIndex == 0 {
+		s, n, err := decodeString(buf)
+		if err != nil {
+			return HeaderField{}, 0, err
+		}
+		name, consumed = s, n
+	} else {
+		f, ok := lookup(dyn, nameIndex)
+		if !ok {
+			return HeaderField{}, 0, fmt.Errorf("hpack: invalid name index %d", nameIndex)
+		}
+		name = f.Name
+	}
+	value, n, err := decodeString(buf[consumed:])
+	if err != nil {
+		return HeaderField{}, 0, err
+	}
+	return HeaderField{Name: name, Value: value}, consumed + n, nil
+}
+
+// EncodeHeaders encodes fields as literal header fields without
+// indexing: always valid HPACK, at the cost of the compression a
+// smarter encoder (Huffman coding, table reuse) would get.
+func EncodeHeaders(fields []HeaderField) []byte {
+	var buf []byte
+	for _, f := range fields {
+		buf = append(buf, 0x00) // literal without indexing, name index 0
+		buf = append(buf, encodeString(f.Name)...)
+		buf = append(buf, encodeString(f.Value)...)
+	}
+	return buf
+}
+
+func encodeString(s string) []byte {
+	return append(encodeInt(len(s), 7, 0), s...)
+}
+
+func encodeInt(value, prefixBits int, firstByteBits byte) []byte {
+	max := (1 << prefixBits) - 1
+	if value < max {
+		return []byte{firstByteBits | byte(value)}
+	}
+	buf := []byte{firstByteBits | byte(max)}
+	value -= max
+	for value >= 0x80 {
+		buf = append(buf, byte(value&0x7f|0x80))
+		value >>= 7
+	}
+	return append(buf, byte(value))
+}
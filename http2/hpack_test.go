@@ -0,0 +1,57 @@
+package http2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecodeHeadersRoundTrip(t *testing.T) {
+	fields := []HeaderField{
+		{Name: ":method", Value: "GET"},
+		{Name: "x-custom", Value: "value"},
+	}
+	encoded := EncodeHeaders(fields)
+
+	dyn := &dynamicTable{maxSize: 4096}
+	decoded, err := DecodeHeaders(encoded, dyn)
+	if err != nil {
+		t.Fatalf("DecodeHeaders: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, fields) {
+		t.Fatalf("decoded = %v, want %v", decoded, fields)
+	}
+}
+
+func TestDecodeHeadersIndexedField(t *testing.T) {
+	// Static table index 2 is {":method", "GET"} (RFC 7541 Appendix A);
+	// 0x82 is that index encoded as an indexed header field.
+	dyn := &dynamicTable{maxSize: 4096}
+	got, err := DecodeHeaders([]byte{0x82}, dyn)
+	if err != nil {
+		t.Fatalf("DecodeHeaders: %v", err)
+	}
+	want := []HeaderField{{Name: ":method", Value: "GET"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got = %v, want %v", got, want)
+	}
+}
+
+func TestDecodeHeadersInvalidIndex(t *testing.T) {
+	dyn := &dynamicTable{maxSize: 4096}
+	if _, err := DecodeHeaders([]byte{0xff, 0x7f}, dyn); err == nil {
+		t.Fatal("expected an error for an out-of-range index")
+	}
+}
+
+func TestDynamicTableAddAndLookup(t *testing.T) {
+	dyn := &dynamicTable{maxSize: 4096}
+	dyn.add(HeaderField{Name: "x-one", Value: "1"})
+
+	f, ok := lookup(dyn, len(staticTable)+1)
+	if !ok {
+		t.Fatal("lookup of the most recently added dynamic entry failed")
+	}
+	if f.Name != "x-one" || f.Value != "1" {
+		t.Fatalf("f = %+v", f)
+	}
+}
@@ -0,0 +1,25 @@
+package http2
+
+import "testing"
+
+func TestHuffmanDecodeRFC7541Example(t *testing.T) {
+	// RFC 7541 appendix C.4.1: ":authority" value "www.example.com"
+	// Huffman-encoded.
+	encoded := []byte{0xf1, 0xe3, 0xc2, 0xe5, 0xf2, 0x3a, 0x6b, 0xa0, 0xab, 0x90, 0xf4, 0xff}
+	got, err := huffmanDecode(encoded)
+	if err != nil {
+		t.Fatalf("huffmanDecode: %v", err)
+	}
+	if want := "www.example.com"; got != want {
+		t.Fatalf("huffmanDecode = %q, want %q", got, want)
+	}
+}
+
+func TestHuffmanDecodeInvalidPadding(t *testing.T) {
+	// A single zero byte decodes its first 5 bits to '0' ('0' is 5 bits
+	// in the Huffman table), leaving 3 padding bits that are 0 rather
+	// than the required all-ones EOS padding.
+	if _, err := huffmanDecode([]byte{0x00}); err == nil {
+		t.Fatal("expected an error for non-EOS padding bits")
+	}
+}
@@ -0,0 +1,58 @@
+package shittp
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+)
+
+// listenTCP creates a non-blocking, listening IPv4 TCP socket bound to
+// addr (e.g. ":8080" or "127.0.0.1:8080") via raw syscalls, for the
+// event-loop transport's accept loop, which manages its own fds instead
+// of going through net.Listen.
+func listenTCP(addr string) (int, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return -1, fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return -1, fmt.Errorf("invalid port %q: %w", portStr, err)
+	}
+
+	var ip [4]byte
+	if host != "" {
+		parsed := net.ParseIP(host)
+		if parsed == nil {
+			return -1, fmt.Errorf("invalid host %q", host)
+		}
+		v4 := parsed.To4()
+		if v4 == nil {
+			return -1, fmt.Errorf("event-loop transport only supports IPv4, got %q", host)
+		}
+		copy(ip[:], v4)
+	}
+
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return -1, err
+	}
+	if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrInet4{Port: port, Addr: ip}); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	if err := syscall.Listen(fd, syscall.SOMAXCONN); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	return fd, nil
+}
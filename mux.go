@@ -0,0 +1,110 @@
+package shittp
+
+import "strings"
+
+// ServeMux routes requests by method and path, with simple
+// "{name}"-style path parameter capture. It implements Handler so it can
+// be dropped into Server.Handler directly.
+type ServeMux struct {
+	routes []*muxRoute
+}
+
+type muxRoute struct {
+	method   string
+	segments []string
+	handler  Handler
+}
+
+// NewServeMux returns an empty ServeMux.
+func NewServeMux() *ServeMux {
+	return &ServeMux{}
+}
+
+// Handle registers handler to be called for requests matching method and
+// pattern. pattern segments wrapped in braces, e.g. "{id}", capture that
+// segment into the request's PathParams.
+func (mux *ServeMux) Handle(method, pattern string, handler Handler) {
+	mux.routes = append(mux.routes, &muxRoute{
+		method:   strings.ToUpper(method),
+		segments: splitPath(pattern),
+		handler:  handler,
+	})
+}
+
+// HandleFunc registers handler to be called for requests matching method
+// and pattern.
+func (mux *ServeMux) HandleFunc(method, pattern string, handler func(ResponseWriter, *HTTPRequest)) {
+	mux.Handle(method, pattern, HandlerFunc(handler))
+}
+
+// ServeHTTP dispatches r to the first registered route whose method and
+// path match, writing a 404 (or 405 if the path matches but the method
+// doesn't) when nothing does.
+func (mux *ServeMux) ServeHTTP(w ResponseWriter, r *HTTPRequest) {
+	path := requestPath(r.URI)
+	segments := splitPath(path)
+
+	pathMatched := false
+	for _, route := range mux.routes {
+		params, ok := matchPath(route.segments, segments)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if route.method != strings.ToUpper(r.Method) {
+			continue
+		}
+		r.PathParams = params
+		route.handler.ServeHTTP(w, r)
+		return
+	}
+
+	if pathMatched {
+		w.WriteHeader(405)
+		w.Write([]byte("Method Not Allowed"))
+		return
+	}
+	w.WriteHeader(404)
+	w.Write([]byte("Not Found"))
+}
+
+// requestPath strips the query string (if any) off a request URI.
+func requestPath(uri string) string {
+	if idx := strings.IndexByte(uri, '?'); idx >= 0 {
+		return uri[:idx]
+	}
+	return uri
+}
+
+// splitPath splits a URL path into its non-empty segments.
+func splitPath(path string) []string {
+	var segments []string
+	for _, seg := range strings.Split(path, "/") {
+		if seg != "" {
+			segments = append(segments, seg)
+		}
+	}
+	return segments
+}
+
+// matchPath compares pattern segments against request path segments,
+// capturing "{name}" segments into the returned params map.
+func matchPath(pattern, path []string) (map[string]string, bool) {
+	if len(pattern) != len(path) {
+		return nil, false
+	}
+	var params map[string]string
+	for i, seg := range pattern {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[seg[1:len(seg)-1]] = path[i]
+			continue
+		}
+		if seg != path[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
@@ -0,0 +1,113 @@
+package shittp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern []string
+		path    []string
+		wantOK  bool
+		want    map[string]string
+	}{
+		{
+			name:    "exact match, no params",
+			pattern: []string{"users"},
+			path:    []string{"users"},
+			wantOK:  true,
+			want:    nil,
+		},
+		{
+			name:    "single capture",
+			pattern: []string{"users", "{id}"},
+			path:    []string{"users", "42"},
+			wantOK:  true,
+			want:    map[string]string{"id": "42"},
+		},
+		{
+			name:    "multiple captures",
+			pattern: []string{"users", "{id}", "posts", "{postID}"},
+			path:    []string{"users", "42", "posts", "7"},
+			wantOK:  true,
+			want:    map[string]string{"id": "42", "postID": "7"},
+		},
+		{
+			name:    "literal segment mismatch",
+			pattern: []string{"users", "{id}"},
+			path:    []string{"groups", "42"},
+			wantOK:  false,
+		},
+		{
+			name:    "different segment counts don't match",
+			pattern: []string{"users", "{id}"},
+			path:    []string{"users", "42", "posts"},
+			wantOK:  false,
+		},
+		{
+			name:    "empty pattern matches empty path",
+			pattern: nil,
+			path:    nil,
+			wantOK:  true,
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := matchPath(tt.pattern, tt.path)
+			if ok != tt.wantOK {
+				t.Fatalf("matchPath(%v, %v) ok = %v, want %v", tt.pattern, tt.path, ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("matchPath(%v, %v) params = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeMuxMethodNotAllowed(t *testing.T) {
+	mux := NewServeMux()
+	mux.HandleFunc("GET", "/widgets/{id}", func(w ResponseWriter, r *HTTPRequest) {
+		w.Write([]byte(r.PathParams["id"]))
+	})
+
+	w := NewResponseRecorder()
+	r := &HTTPRequest{Method: "POST", URI: "/widgets/5"}
+	mux.ServeHTTP(w, r)
+
+	if resp := w.Response(); resp.Status != 405 {
+		t.Fatalf("status = %d, want 405", resp.Status)
+	}
+}
+
+func TestServeMuxNotFound(t *testing.T) {
+	mux := NewServeMux()
+	mux.HandleFunc("GET", "/widgets/{id}", func(w ResponseWriter, r *HTTPRequest) {})
+
+	w := NewResponseRecorder()
+	r := &HTTPRequest{Method: "GET", URI: "/gadgets/5"}
+	mux.ServeHTTP(w, r)
+
+	if resp := w.Response(); resp.Status != 404 {
+		t.Fatalf("status = %d, want 404", resp.Status)
+	}
+}
+
+func TestServeMuxCapturesPathParams(t *testing.T) {
+	mux := NewServeMux()
+	var gotID string
+	mux.HandleFunc("GET", "/widgets/{id}", func(w ResponseWriter, r *HTTPRequest) {
+		gotID = r.PathParams["id"]
+	})
+
+	w := NewResponseRecorder()
+	r := &HTTPRequest{Method: "GET", URI: "/widgets/5?verbose=1"}
+	mux.ServeHTTP(w, r)
+
+	if gotID != "5" {
+		t.Fatalf("PathParams[id] = %q, want %q", gotID, "5")
+	}
+}
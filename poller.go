@@ -0,0 +1,12 @@
+package shittp
+
+// poller abstracts the OS-specific readiness notification mechanism
+// (epoll on Linux, kqueue on BSD/Darwin) behind the handful of
+// operations an eventLoop needs. newPoller is implemented per-platform.
+type poller interface {
+	add(fd int) error
+	remove(fd int) error
+	// wait blocks until one or more registered fds are readable and
+	// returns them.
+	wait() ([]int, error)
+}
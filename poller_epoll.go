@@ -0,0 +1,47 @@
+//go:build linux
+
+package shittp
+
+import "syscall"
+
+// epollPoller is the Linux poller implementation.
+type epollPoller struct {
+	fd     int
+	events []syscall.EpollEvent
+}
+
+func newPoller() (poller, error) {
+	fd, err := syscall.EpollCreate1(0)
+	if err != nil {
+		return nil, err
+	}
+	return &epollPoller{fd: fd, events: make([]syscall.EpollEvent, 256)}, nil
+}
+
+func (p *epollPoller) add(fd int) error {
+	return syscall.EpollCtl(p.fd, syscall.EPOLL_CTL_ADD, fd, &syscall.EpollEvent{
+		Events: syscall.EPOLLIN,
+		Fd:     int32(fd),
+	})
+}
+
+func (p *epollPoller) remove(fd int) error {
+	return syscall.EpollCtl(p.fd, syscall.EPOLL_CTL_DEL, fd, nil)
+}
+
+func (p *epollPoller) wait() ([]int, error) {
+	for {
+		n, err := syscall.EpollWait(p.fd, p.events, -1)
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			return nil, err
+		}
+		ready := make([]int, n)
+		for i := 0; i < n; i++ {
+			ready[i] = int(p.events[i].Fd)
+		}
+		return ready, nil
+	}
+}
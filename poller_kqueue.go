@@ -0,0 +1,48 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package shittp
+
+import "syscall"
+
+// kqueuePoller is the BSD/Darwin poller implementation.
+type kqueuePoller struct {
+	fd int
+}
+
+func newPoller() (poller, error) {
+	fd, err := syscall.Kqueue()
+	if err != nil {
+		return nil, err
+	}
+	return &kqueuePoller{fd: fd}, nil
+}
+
+func (p *kqueuePoller) add(fd int) error {
+	ev := syscall.Kevent_t{Ident: uint64(fd), Filter: syscall.EVFILT_READ, Flags: syscall.EV_ADD | syscall.EV_ENABLE}
+	_, err := syscall.Kevent(p.fd, []syscall.Kevent_t{ev}, nil, nil)
+	return err
+}
+
+func (p *kqueuePoller) remove(fd int) error {
+	ev := syscall.Kevent_t{Ident: uint64(fd), Filter: syscall.EVFILT_READ, Flags: syscall.EV_DELETE}
+	_, err := syscall.Kevent(p.fd, []syscall.Kevent_t{ev}, nil, nil)
+	return err
+}
+
+func (p *kqueuePoller) wait() ([]int, error) {
+	events := make([]syscall.Kevent_t, 256)
+	for {
+		n, err := syscall.Kevent(p.fd, nil, events, nil)
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			return nil, err
+		}
+		ready := make([]int, n)
+		for i := 0; i < n; i++ {
+			ready[i] = int(events[i].Ident)
+		}
+		return ready, nil
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package shittp
+
+import "fmt"
+
+func newPoller() (poller, error) {
+	return nil, fmt.Errorf("event-loop transport is not supported on this platform")
+}
@@ -0,0 +1,278 @@
+package shittp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// statusError is an error that carries the HTTP status it should be
+// reported as, so callers can respond with something more useful than a
+// blanket 500.
+type statusError struct {
+	Status int
+	Reason string
+	err    error
+}
+
+func (e *statusError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("%d %s: %v", e.Status, e.Reason, e.err)
+	}
+	return fmt.Sprintf("%d %s", e.Status, e.Reason)
+}
+
+func (e *statusError) Unwrap() error { return e.err }
+
+func badRequest(err error) *statusError {
+	return &statusError{Status: 400, Reason: "Bad Request", err: err}
+}
+
+func headerTooLarge(err error) *statusError {
+	return &statusError{Status: 431, Reason: "Request Header Fields Too Large", err: err}
+}
+
+func bodyTooLarge(err error) *statusError {
+	return &statusError{Status: 413, Reason: "Payload Too Large", err: err}
+}
+
+// errLineTooLong is returned by readLimitedLine when a line grows past
+// its limit without a terminating '\n'.
+var errLineTooLong = errors.New("line exceeds limit")
+
+// readLimitedLine reads a single '\n'-terminated line, aborting as soon
+// as it has read more than limit bytes rather than buffering an
+// unbounded amount of data from a client that never sends a newline.
+func readLimitedLine(br *bufio.Reader, limit int) (string, error) {
+	var line []byte
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				// Some of the line had already arrived; a clean EOF here
+				// means the peer vanished mid-line, not that it simply
+				// closed between requests.
+				err = io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+		if len(line) >= limit {
+			return "", errLineTooLong
+		}
+		line = append(line, b)
+		if b == '\n' {
+			return string(line), nil
+		}
+	}
+}
+
+// readRequestLine reads the request line, enforcing maxLine bytes
+// (including the trailing CRLF).
+func readRequestLine(br *bufio.Reader, maxLine int) (string, error) {
+	line, err := readLimitedLine(br, maxLine)
+	if err != nil {
+		if err == errLineTooLong {
+			return "", &statusError{Status: 414, Reason: "URI Too Long"}
+		}
+		return "", badRequest(err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readHeaderLines reads header lines until the terminating blank line,
+// enforcing maxHeaderBytes across the whole header block.
+func readHeaderLines(br *bufio.Reader, maxHeaderBytes int) ([]string, error) {
+	var lines []string
+	remaining := maxHeaderBytes
+	for {
+		line, err := readLimitedLine(br, remaining)
+		if err != nil {
+			if err == errLineTooLong {
+				return nil, headerTooLarge(fmt.Errorf("header block exceeds %d bytes", maxHeaderBytes))
+			}
+			return nil, badRequest(err)
+		}
+		remaining -= len(line)
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func (r *HTTPRequest) parseRequest(line string) error {
+	parts := strings.Split(line, " ")
+	if len(parts) != 3 {
+		return badRequest(fmt.Errorf("invalid request line: %s", line))
+	}
+	r.Method = parts[0]
+	r.URI = parts[1]
+	r.Version = parts[2]
+	return nil
+}
+
+// parseHeaders parses lines into r.Headers, canonicalizing each name
+// (e.g. "content-length" and "Content-Length" both become
+// "Content-Length") since HTTP header names are case-insensitive per
+// RFC 7230 section 3.2 but every lookup in this package matches on an
+// exact canonical key.
+func (r *HTTPRequest) parseHeaders(lines []string) error {
+	r.Headers = make(map[string]string)
+
+	for _, line := range lines {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+		r.Headers[key] = value
+	}
+	return nil
+}
+
+// shouldKeepAlive reports whether the connection req arrived on should
+// stay open for another request. HTTP/1.1 defaults to persistent
+// connections unless the client sends "Connection: close"; HTTP/1.0
+// defaults to closing unless the client opts in with
+// "Connection: keep-alive".
+func shouldKeepAlive(r *HTTPRequest) bool {
+	conn := strings.ToLower(strings.TrimSpace(r.Headers["Connection"]))
+	switch {
+	case conn == "close":
+		return false
+	case conn == "keep-alive":
+		return true
+	case strings.HasSuffix(r.Version, "1.0"):
+		return false
+	default:
+		return true
+	}
+}
+
+// isChunked reports whether the request declares a chunked transfer
+// encoding via the Transfer-Encoding header.
+func (r *HTTPRequest) isChunked() bool {
+	te := strings.ToLower(r.Headers["Transfer-Encoding"])
+	for _, enc := range strings.Split(te, ",") {
+		if strings.TrimSpace(enc) == "chunked" {
+			return true
+		}
+	}
+	return false
+}
+
+// readBody reads the request body following RFC 7230: chunked transfer
+// encoding takes priority over Content-Length when both are present.
+// maxHeaderBytes bounds the chunked path's framing lines (chunk-size
+// lines and trailer headers); maxBodySize bounds the decoded body.
+func (r *HTTPRequest) readBody(br *bufio.Reader, maxHeaderBytes int, maxBodySize int64) error {
+	if r.isChunked() {
+		body, err := readChunkedBody(br, maxHeaderBytes, maxBodySize)
+		if err != nil {
+			return err
+		}
+		r.Body = body
+		return nil
+	}
+
+	cl, ok := r.Headers["Content-Length"]
+	if !ok {
+		r.Body = nil
+		return nil
+	}
+	length, err := strconv.ParseInt(cl, 10, 64)
+	if err != nil || length < 0 {
+		return badRequest(fmt.Errorf("invalid Content-Length: %s", cl))
+	}
+	if length > maxBodySize {
+		return bodyTooLarge(fmt.Errorf("body of %d bytes exceeds limit of %d", length, maxBodySize))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(br, body); err != nil {
+		return badRequest(err)
+	}
+	r.Body = body
+	return nil
+}
+
+// readChunkedBody decodes a chunked transfer-coded body: a sequence of
+// hex-length-prefixed chunks terminated by a zero-length chunk, followed
+// by an optional block of trailer headers. maxHeaderBytes bounds each
+// chunk-size line and the trailer block, the same way it bounds the main
+// header block, so a line with no '\n' can't buffer unbounded memory.
+func readChunkedBody(br *bufio.Reader, maxHeaderBytes int, maxBodySize int64) ([]byte, error) {
+	var body []byte
+	var total int64
+
+	for {
+		sizeLine, err := readLimitedLine(br, maxHeaderBytes)
+		if err != nil {
+			if err == errLineTooLong {
+				return nil, headerTooLarge(fmt.Errorf("chunk size line exceeds %d bytes", maxHeaderBytes))
+			}
+			return nil, badRequest(err)
+		}
+		sizeLine = strings.TrimRight(sizeLine, "\r\n")
+		if idx := strings.IndexByte(sizeLine, ';'); idx >= 0 {
+			sizeLine = sizeLine[:idx] // drop chunk extensions
+		}
+		size, err := strconv.ParseInt(sizeLine, 16, 64)
+		if err != nil || size < 0 {
+			return nil, badRequest(fmt.Errorf("invalid chunk size: %q", sizeLine))
+		}
+		if size == 0 {
+			break
+		}
+		total += size
+		if total > maxBodySize {
+			return nil, bodyTooLarge(fmt.Errorf("chunked body exceeds limit of %d", maxBodySize))
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(br, chunk); err != nil {
+			return nil, badRequest(err)
+		}
+		body = append(body, chunk...)
+		if _, err := readCRLF(br); err != nil {
+			return nil, badRequest(err)
+		}
+	}
+
+	// Optional trailer headers, terminated by a blank line, same as the
+	// main header block.
+	remaining := maxHeaderBytes
+	for {
+		line, err := readLimitedLine(br, remaining)
+		if err != nil {
+			if err == errLineTooLong {
+				return nil, headerTooLarge(fmt.Errorf("trailer block exceeds %d bytes", maxHeaderBytes))
+			}
+			return nil, badRequest(err)
+		}
+		remaining -= len(line)
+		if strings.TrimRight(line, "\r\n") == "" {
+			break
+		}
+	}
+
+	return body, nil
+}
+
+// readCRLF consumes the CRLF that follows each chunk's data.
+func readCRLF(br *bufio.Reader) (int, error) {
+	buf := make([]byte, 2)
+	n, err := io.ReadFull(br, buf)
+	if err != nil {
+		return n, err
+	}
+	if buf[0] != '\r' || buf[1] != '\n' {
+		return n, fmt.Errorf("expected CRLF after chunk data, got %q", buf)
+	}
+	return n, nil
+}
@@ -0,0 +1,169 @@
+package shittp
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestReadChunkedBody(t *testing.T) {
+	raw := "4\r\nWiki\r\n5\r\npedia\r\n0\r\n\r\n"
+	br := bufio.NewReader(strings.NewReader(raw))
+
+	body, err := readChunkedBody(br, 1<<20, 1<<20)
+	if err != nil {
+		t.Fatalf("readChunkedBody: %v", err)
+	}
+	if got, want := string(body), "Wikipedia"; got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
+
+func TestReadChunkedBodyWithTrailer(t *testing.T) {
+	raw := "4\r\nWiki\r\n0\r\nX-Trailer: ok\r\n\r\n"
+	br := bufio.NewReader(strings.NewReader(raw))
+
+	body, err := readChunkedBody(br, 1<<20, 1<<20)
+	if err != nil {
+		t.Fatalf("readChunkedBody: %v", err)
+	}
+	if got, want := string(body), "Wiki"; got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
+
+func TestReadChunkedBodyExceedsMaxBodySize(t *testing.T) {
+	raw := "4\r\nWiki\r\n5\r\npedia\r\n0\r\n\r\n"
+	br := bufio.NewReader(strings.NewReader(raw))
+
+	_, err := readChunkedBody(br, 1<<20, 4)
+	se, ok := err.(*statusError)
+	if !ok {
+		t.Fatalf("err = %v (%T), want *statusError", err, err)
+	}
+	if se.Status != 413 {
+		t.Fatalf("status = %d, want 413", se.Status)
+	}
+}
+
+func TestReadChunkedBodyInvalidSize(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("zz\r\n"))
+	if _, err := readChunkedBody(br, 1<<20, 1<<20); err == nil {
+		t.Fatal("expected an error for a non-hex chunk size")
+	}
+}
+
+func TestReadChunkedBodyMissingCRLF(t *testing.T) {
+	raw := "4\r\nWikiXX0\r\n\r\n"
+	br := bufio.NewReader(strings.NewReader(raw))
+	if _, err := readChunkedBody(br, 1<<20, 1<<20); err == nil {
+		t.Fatal("expected an error when the chunk isn't terminated by CRLF")
+	}
+}
+
+func TestReadRequestLineEnforcesMaxLine(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("GET /a-very-long-uri-indeed HTTP/1.1\r\n"))
+	_, err := readRequestLine(br, 10)
+	se, ok := err.(*statusError)
+	if !ok {
+		t.Fatalf("err = %v (%T), want *statusError", err, err)
+	}
+	if se.Status != 414 {
+		t.Fatalf("status = %d, want 414", se.Status)
+	}
+}
+
+func TestReadRequestLineBoundsUnterminatedInput(t *testing.T) {
+	// No trailing '\n' at all: readRequestLine must stop reading once
+	// it has seen more than maxLine bytes rather than buffering the
+	// rest of the (arbitrarily long) input looking for one.
+	huge := strings.Repeat("A", 1<<20)
+	br := bufio.NewReader(strings.NewReader(huge))
+
+	_, err := readRequestLine(br, 16)
+	se, ok := err.(*statusError)
+	if !ok {
+		t.Fatalf("err = %v (%T), want *statusError", err, err)
+	}
+	if se.Status != 414 {
+		t.Fatalf("status = %d, want 414", se.Status)
+	}
+}
+
+func TestReadHeaderLinesEnforcesMaxHeaderBytes(t *testing.T) {
+	raw := "X-One: 1\r\nX-Two: 2\r\nX-Three: 3\r\n\r\n"
+	br := bufio.NewReader(strings.NewReader(raw))
+
+	_, err := readHeaderLines(br, 10)
+	se, ok := err.(*statusError)
+	if !ok {
+		t.Fatalf("err = %v (%T), want *statusError", err, err)
+	}
+	if se.Status != 431 {
+		t.Fatalf("status = %d, want 431", se.Status)
+	}
+}
+
+func TestReadHeaderLinesStopsAtBlankLine(t *testing.T) {
+	raw := "X-One: 1\r\nX-Two: 2\r\n\r\nnot-a-header-line"
+	br := bufio.NewReader(strings.NewReader(raw))
+
+	lines, err := readHeaderLines(br, 1<<20)
+	if err != nil {
+		t.Fatalf("readHeaderLines: %v", err)
+	}
+	want := []string{"X-One: 1", "X-Two: 2"}
+	if len(lines) != len(want) {
+		t.Fatalf("lines = %v, want %v", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("lines[%d] = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestParseHeadersCanonicalizesKeys(t *testing.T) {
+	r := &HTTPRequest{}
+	err := r.parseHeaders([]string{"content-length: 5", "X-Custom-Header: ok"})
+	if err != nil {
+		t.Fatalf("parseHeaders: %v", err)
+	}
+	if got, want := r.Headers["Content-Length"], "5"; got != want {
+		t.Fatalf("Headers[Content-Length] = %q, want %q", got, want)
+	}
+	if got, want := r.Headers["X-Custom-Header"], "ok"; got != want {
+		t.Fatalf("Headers[X-Custom-Header] = %q, want %q", got, want)
+	}
+}
+
+func TestReadChunkedBodyBoundsSizeLine(t *testing.T) {
+	// No '\n' anywhere in the chunk-size field: readChunkedBody must stop
+	// reading once it has seen more than the limit rather than buffering
+	// the rest of this (arbitrarily long) input looking for one.
+	huge := strings.Repeat("f", 1<<20)
+	br := bufio.NewReader(strings.NewReader(huge))
+
+	_, err := readChunkedBody(br, 16, 1<<20)
+	se, ok := err.(*statusError)
+	if !ok {
+		t.Fatalf("err = %v (%T), want *statusError", err, err)
+	}
+	if se.Status != 431 {
+		t.Fatalf("status = %d, want 431", se.Status)
+	}
+}
+
+func TestReadChunkedBodyBoundsTrailer(t *testing.T) {
+	raw := "0\r\n" + strings.Repeat("X-Trailer: padding\r\n", 1000)
+	br := bufio.NewReader(strings.NewReader(raw))
+
+	_, err := readChunkedBody(br, 64, 1<<20)
+	se, ok := err.(*statusError)
+	if !ok {
+		t.Fatalf("err = %v (%T), want *statusError", err, err)
+	}
+	if se.Status != 431 {
+		t.Fatalf("status = %d, want 431", se.Status)
+	}
+}
@@ -0,0 +1,34 @@
+package shittp
+
+import (
+	"fmt"
+	"net"
+)
+
+func (r *HTTPResponse) writeResponse(w net.Conn) error {
+	header := fmt.Sprintf("HTTP/%s %d %s\r\n", r.Version, r.Status, r.Reason)
+	for key, value := range r.Headers {
+		header += fmt.Sprintf("%s: %s\r\n", key, value)
+	}
+	header += "\r\n"
+	w.Write([]byte(header))
+	w.Write(r.Body)
+	return nil
+}
+
+// writeStatusError writes a plain-text response for the given status,
+// e.g. the 4xx errors returned by request parsing.
+func writeStatusError(conn net.Conn, se *statusError) error {
+	body := []byte(se.Reason)
+	resp := &HTTPResponse{
+		Version: "1.1",
+		Status:  se.Status,
+		Reason:  se.Reason,
+		Headers: map[string]string{
+			"Content-Type":   "text/plain",
+			"Content-Length": fmt.Sprint(len(body)),
+		},
+		Body: body,
+	}
+	return resp.writeResponse(conn)
+}
@@ -0,0 +1,268 @@
+package shittp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Defaults mirror what most HTTP servers ship with: generous enough for
+// real requests, small enough to stop a client from exhausting memory.
+const (
+	defaultMaxRequestLineSize = 8 * 1024
+	defaultMaxHeaderBytes     = 1 << 20  // 1MB
+	defaultMaxBodySize        = 10 << 20 // 10MB
+
+	defaultReadTimeout  = 30 * time.Second
+	defaultWriteTimeout = 30 * time.Second
+	defaultIdleTimeout  = 120 * time.Second
+
+	// defaultEventLoopWorkers sizes the handler worker pool used by the
+	// event-loop transport. It's deliberately much larger than NumCPU:
+	// handlers (including the CGI gateway, which shells out
+	// synchronously) block on I/O far more than they burn CPU, and the
+	// pool exists precisely so one slow handler can't stall the pollers.
+	defaultEventLoopWorkers = 256
+)
+
+// Server holds the configuration for a running shittp server.
+type Server struct {
+	Addr string
+
+	// MaxRequestLineSize caps the length of the request line, in bytes.
+	MaxRequestLineSize int
+	// MaxHeaderBytes caps the total size of the header block, in bytes.
+	MaxHeaderBytes int
+	// MaxBodySize caps the size of the request body, in bytes.
+	MaxBodySize int64
+
+	// The three timeouts below are applied by the goroutine-per-conn
+	// and TLS serve loops; the event-loop transport sets no deadlines.
+	//
+	// ReadTimeout bounds how long reading a single request (line,
+	// headers and body) may take.
+	ReadTimeout time.Duration
+	// WriteTimeout bounds how long writing the response may take.
+	WriteTimeout time.Duration
+	// IdleTimeout bounds how long a keep-alive connection may sit idle
+	// between requests before the server closes it.
+	IdleTimeout time.Duration
+
+	// Handler dispatches requests. If nil, DefaultServeMux is used.
+	Handler Handler
+
+	// Transport selects the connection-handling strategy. The zero
+	// value is TransportGoroutinePerConn.
+	Transport Transport
+	// Multicore shards accepted connections across one event-loop
+	// goroutine per CPU instead of a single loop. Only applies when
+	// Transport is TransportEventLoop.
+	Multicore bool
+	// Workers sets the size of the handler worker pool shared by all
+	// event loops. Only applies when Transport is TransportEventLoop.
+	Workers int
+}
+
+// NewServer returns a Server configured with sane defaults, listening on
+// addr.
+func NewServer(addr string) *Server {
+	return &Server{
+		Addr:               addr,
+		MaxRequestLineSize: defaultMaxRequestLineSize,
+		MaxHeaderBytes:     defaultMaxHeaderBytes,
+		MaxBodySize:        defaultMaxBodySize,
+		ReadTimeout:        defaultReadTimeout,
+		WriteTimeout:       defaultWriteTimeout,
+		IdleTimeout:        defaultIdleTimeout,
+		Workers:            defaultEventLoopWorkers,
+	}
+}
+
+// ListenAndServe listens on s.Addr and serves requests using whichever
+// Transport is configured. It returns only if the listener can't be set
+// up; an error accepting a single connection is logged and the accept
+// loop keeps going.
+func (s *Server) ListenAndServe() error {
+	switch s.Transport {
+	case TransportEventLoop:
+		return s.serveEventLoop()
+	default:
+		return s.serveGoroutinePerConn()
+	}
+}
+
+// serveGoroutinePerConn is the default transport: one goroutine per
+// accepted connection, each running the serve loop below.
+func (s *Server) serveGoroutinePerConn() error {
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		log.Error("failed to create tcp connection", "error", err.Error())
+		return err
+	}
+	log.Info("Server listening on " + s.Addr)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Error("failed to listen tcp connection", "error", err.Error())
+			continue
+		}
+		go s.serve(conn)
+	}
+}
+
+// parseIncomingRequest reads and parses a single request off br,
+// enforcing the server's configured limits.
+func (s *Server) parseIncomingRequest(br *bufio.Reader) (*HTTPRequest, error) {
+	line, err := readRequestLine(br, s.MaxRequestLineSize)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &HTTPRequest{}
+	if err := req.parseRequest(line); err != nil {
+		return nil, err
+	}
+
+	headerLines, err := readHeaderLines(br, s.MaxHeaderBytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.parseHeaders(headerLines); err != nil {
+		return nil, err
+	}
+
+	if err := req.readBody(br, s.MaxHeaderBytes, s.MaxBodySize); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// serve runs the per-connection loop: it reads and handles successive
+// requests off conn, keeping the connection open across requests per
+// HTTP/1.1 semantics until the client asks to close it, an error occurs,
+// or the connection sits idle past s.IdleTimeout.
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	br := bufio.NewReader(conn)
+	for first := true; ; first = false {
+		if first {
+			conn.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+		} else {
+			conn.SetReadDeadline(time.Now().Add(s.IdleTimeout))
+			if _, err := br.Peek(1); err == nil {
+				// The next request has started arriving: the idle
+				// wait is over, so it gets the full ReadTimeout.
+				conn.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+			}
+		}
+
+		req, err := s.parseIncomingRequest(br)
+		if err != nil {
+			if !first && isTimeout(err) {
+				// Idle keep-alive connection timed out; close quietly.
+				return
+			}
+			if !first && isClosedCleanly(err) {
+				// The client closed the connection after a prior
+				// response instead of sending Connection: close or
+				// another request; this is the normal, overwhelmingly
+				// common way a keep-alive connection ends.
+				return
+			}
+			if se, ok := err.(*statusError); ok {
+				log.Error("failed to parse request", "error", se.Error())
+				conn.SetWriteDeadline(time.Now().Add(s.WriteTimeout))
+				if werr := writeStatusError(conn, se); werr != nil {
+					log.Error("failed to write error response", "error", werr.Error())
+				}
+				return
+			}
+			log.Error("failed to parse request", "error", err.Error())
+			handleFailure(conn)
+			return
+		}
+
+		fmt.Printf("Request: %s %s %s\n", req.Method, req.URI, req.Version)
+		fmt.Println("Headers:")
+		for key, value := range req.Headers {
+			fmt.Printf("  %s: %s\n", key, value)
+		}
+
+		keepAlive := shouldKeepAlive(req)
+
+		handler := s.Handler
+		if handler == nil {
+			handler = DefaultServeMux
+		}
+		rw := NewResponseRecorder()
+		handler.ServeHTTP(rw, req)
+		resp := rw.Response()
+		if !keepAlive {
+			resp.Headers["Connection"] = "close"
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(s.WriteTimeout))
+		if err := resp.writeResponse(conn); err != nil {
+			log.Error("failed to write response", "error", err.Error())
+			return
+		}
+		log.Info("response served", "resp", resp)
+
+		if !keepAlive {
+			return
+		}
+	}
+}
+
+// isClosedCleanly reports whether err is a plain io.EOF, i.e. the peer
+// closed the socket without sending anything further. It doesn't check
+// where the EOF was hit: one that lands right at the start of a header
+// line or of a Content-Length body matches too, not only one at the
+// start of a new request.
+func isClosedCleanly(err error) bool {
+	if se, ok := err.(*statusError); ok {
+		err = se.Unwrap()
+	}
+	return errors.Is(err, io.EOF)
+}
+
+// isTimeout reports whether err wraps a net.Error timeout, such as one
+// produced by a read deadline set on an idle keep-alive connection.
+func isTimeout(err error) bool {
+	var ne interface{ Timeout() bool }
+	if se, ok := err.(*statusError); ok {
+		err = se.Unwrap()
+	}
+	if errors.As(err, &ne) {
+		return ne.Timeout()
+	}
+	return false
+}
+
+func handleFailure(conn net.Conn) {
+	defer conn.Close()
+	body := []byte("Internal Server Error")
+	length := len(body)
+
+	resp := &HTTPResponse{
+		Version: "1.1",
+		Status:  500,
+		Reason:  "OK",
+		Headers: map[string]string{
+			"Content-Type":   "text/plain",
+			"Content-Length": fmt.Sprint(length),
+		},
+		Body: body,
+	}
+
+	err := resp.writeResponse(conn)
+	if err != nil {
+		log.Error("failed to write response", "error", err.Error())
+		return
+	}
+}
@@ -0,0 +1,107 @@
+package shittp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func testServer() *Server {
+	s := NewServer(":0")
+	s.ReadTimeout = time.Second
+	s.WriteTimeout = time.Second
+	s.IdleTimeout = time.Second
+	s.Handler = HandlerFunc(func(w ResponseWriter, r *HTTPRequest) {
+		w.Write([]byte(r.URI))
+	})
+	return s
+}
+
+// readResponse reads a single HTTP response off br and returns its body,
+// using the Content-Length every response from this server carries.
+func readResponse(t *testing.T, br *bufio.Reader) string {
+	t.Helper()
+	tp := textproto.NewReader(br)
+	statusLine, err := tp.ReadLine()
+	if err != nil {
+		t.Fatalf("reading status line: %v", err)
+	}
+	if statusLine != "HTTP/1.1 200 OK" {
+		t.Fatalf("status line = %q, want %q", statusLine, "HTTP/1.1 200 OK")
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("reading headers: %v", err)
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		t.Fatalf("parsing Content-Length: %v", err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(br, body); err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	return string(body)
+}
+
+// TestServeKeepAlivePipelining sends two requests back to back on the
+// same connection without waiting for the first response, then asks the
+// server to close the connection, exercising the keep-alive loop in
+// serve.
+func TestServeKeepAlivePipelining(t *testing.T) {
+	client, conn := net.Pipe()
+	s := testServer()
+	done := make(chan struct{})
+	go func() {
+		s.serve(conn)
+		close(done)
+	}()
+
+	go client.Write([]byte(
+		"GET /first HTTP/1.1\r\nHost: x\r\n\r\n" +
+			"GET /second HTTP/1.1\r\nConnection: close\r\n\r\n",
+	))
+
+	br := bufio.NewReader(client)
+	if got := readResponse(t, br); got != "/first" {
+		t.Fatalf("first body = %q, want %q", got, "/first")
+	}
+	if got := readResponse(t, br); got != "/second" {
+		t.Fatalf("second body = %q, want %q", got, "/second")
+	}
+
+	client.Close()
+	<-done
+}
+
+// TestServeClosesCleanlyOnEOF exercises isClosedCleanly: a client that
+// disconnects right after a response, instead of sending
+// Connection: close or another request, should make serve return
+// quietly rather than logging a parse error.
+func TestServeClosesCleanlyOnEOF(t *testing.T) {
+	client, conn := net.Pipe()
+	s := testServer()
+	done := make(chan struct{})
+	go func() {
+		s.serve(conn)
+		close(done)
+	}()
+
+	go client.Write([]byte("GET /only HTTP/1.1\r\nHost: x\r\n\r\n"))
+
+	br := bufio.NewReader(client)
+	if got := readResponse(t, br); got != "/only" {
+		t.Fatalf("body = %q, want %q", got, "/only")
+	}
+
+	client.Close()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("serve did not return after client closed the connection")
+	}
+}
@@ -0,0 +1,90 @@
+package shittp
+
+import (
+	"crypto/tls"
+
+	"github.com/PrashantBtkl/shittp/http2"
+)
+
+// ListenAndServeTLS listens on s.Addr, terminates TLS using the given
+// certificate and key, and serves requests. It returns only if the
+// certificate or listener can't be set up; an error accepting a single
+// connection is logged and the accept loop keeps going. It negotiates "h2" via ALPN alongside "http/1.1": a
+// connection that upgrades to HTTP/2 is handed to the http2 package,
+// everything else falls back to the existing HTTP/1.1 serve loop.
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	ln, err := tls.Listen("tcp", s.Addr, config)
+	if err != nil {
+		log.Error("failed to create tls listener", "error", err.Error())
+		return err
+	}
+	log.Info("Server listening with TLS on " + s.Addr)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Error("failed to accept tls connection", "error", err.Error())
+			continue
+		}
+		go s.serveTLSConn(conn.(*tls.Conn))
+	}
+}
+
+// serveTLSConn completes the handshake, inspects the ALPN result, and
+// dispatches to the protocol-appropriate serve loop.
+func (s *Server) serveTLSConn(conn *tls.Conn) {
+	if err := conn.Handshake(); err != nil {
+		log.Error("tls handshake failed", "error", err.Error())
+		conn.Close()
+		return
+	}
+
+	if conn.ConnectionState().NegotiatedProtocol == "h2" {
+		defer conn.Close()
+		handler := s.Handler
+		if handler == nil {
+			handler = DefaultServeMux
+		}
+		if err := http2.Serve(conn, http2HandlerAdapter{handler}); err != nil {
+			log.Error("http2 connection error", "error", err.Error())
+		}
+		return
+	}
+
+	s.serve(conn)
+}
+
+// http2HandlerAdapter lets an http2.Serve connection dispatch into an
+// ordinary shittp Handler, so user code stays protocol-agnostic. It
+// lives here (rather than in http2, which shittp already depends on)
+// to avoid an import cycle between the two packages.
+type http2HandlerAdapter struct {
+	handler Handler
+}
+
+func (a http2HandlerAdapter) ServeHTTP(w http2.ResponseWriter, r *http2.Request) {
+	req := &HTTPRequest{
+		Method:  r.Method,
+		URI:     r.Path,
+		Version: "2.0",
+		Headers: r.Headers,
+		Body:    r.Body,
+	}
+	rw := NewResponseRecorder()
+	a.handler.ServeHTTP(rw, req)
+	resp := rw.Response()
+
+	for k, v := range resp.Headers {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
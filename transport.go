@@ -0,0 +1,18 @@
+package shittp
+
+// Transport selects how a Server accepts and drives connections.
+type Transport int
+
+const (
+	// TransportGoroutinePerConn spawns one goroutine per accepted
+	// connection. Simple, and cheap enough for most workloads thanks to
+	// Go's scheduler, but it still pays one goroutine (and its stack)
+	// per idle keep-alive connection.
+	TransportGoroutinePerConn Transport = iota
+	// TransportEventLoop runs a small, fixed number of event-loop
+	// goroutines, each owning an epoll (Linux) or kqueue (BSD/Darwin)
+	// instance, and only does work when a connection actually has bytes
+	// to read. Better suited to tens of thousands of mostly-idle
+	// long-lived connections.
+	TransportEventLoop
+)
@@ -0,0 +1,29 @@
+package shittp
+
+import (
+	"log/slog"
+	"os"
+)
+
+var log = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+type HTTPRequest struct {
+	Method  string
+	URI     string
+	Version string
+	Headers map[string]string
+	Body    []byte
+
+	// PathParams holds values captured from named segments in the
+	// matched ServeMux pattern, e.g. {"id": "42"} for a request to
+	// "/users/42" matched against "/users/{id}".
+	PathParams map[string]string
+}
+
+type HTTPResponse struct {
+	Version string
+	Status  int
+	Reason  string
+	Headers map[string]string
+	Body    []byte
+}